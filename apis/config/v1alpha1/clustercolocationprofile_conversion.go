@@ -0,0 +1,23 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha1
+
+// Hub marks ClusterColocationProfile as the conversion hub for this CRD's API versions.
+// Every other served version (currently v1beta1) implements conversion.Convertible against
+// this type instead of against each other, so adding a third version only needs one more
+// ConvertTo/ConvertFrom pair.
+func (*ClusterColocationProfile) Hub() {}
@@ -0,0 +1,100 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/koordinator-sh/koordinator/apis/config/v1alpha1"
+)
+
+// restoreAnnotation stores the v1beta1-only fields that have no v1alpha1 equivalent
+// (currently just Strategy) so that a round-trip through v1alpha1 and back is lossless,
+// the same pattern conversion-gen based projects use for down-conversion of new fields.
+const restoreAnnotation = "config.koordinator.sh/v1beta1-conversion-data"
+
+// ConvertTo converts this ClusterColocationProfile (v1beta1) to the Hub version (v1alpha1).
+func (src *ClusterColocationProfile) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.ClusterColocationProfile)
+	if !ok {
+		return fmt.Errorf("ConvertTo: expected *v1alpha1.ClusterColocationProfile, got %T", dstRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	dst.Spec = v1alpha1.ClusterColocationProfileSpec{
+		NamespaceSelector:   src.Spec.NamespaceSelector,
+		Selector:            src.Spec.Selector,
+		QoSClass:            src.Spec.QoSClass,
+		Priority:            src.Spec.Priority,
+		PriorityClassName:   src.Spec.PriorityClassName,
+		KoordinatorPriority: src.Spec.KoordinatorPriority,
+		SchedulerName:       src.Spec.SchedulerName,
+		Labels:              src.Spec.Labels,
+		Annotations:         src.Spec.Annotations,
+		Patch:               src.Spec.Patch,
+		Paused:              src.Spec.Paused,
+	}
+
+	if src.Spec.Strategy != nil {
+		data, err := json.Marshal(src.Spec.Strategy)
+		if err != nil {
+			return fmt.Errorf("failed to marshal v1beta1-only fields for round-trip: %w", err)
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[restoreAnnotation] = string(data)
+	}
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1alpha1) to this ClusterColocationProfile (v1beta1).
+func (dst *ClusterColocationProfile) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.ClusterColocationProfile)
+	if !ok {
+		return fmt.Errorf("ConvertFrom: expected *v1alpha1.ClusterColocationProfile, got %T", srcRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	dst.Spec = ClusterColocationProfileSpec{
+		NamespaceSelector:   src.Spec.NamespaceSelector,
+		Selector:            src.Spec.Selector,
+		QoSClass:            src.Spec.QoSClass,
+		Priority:            src.Spec.Priority,
+		PriorityClassName:   src.Spec.PriorityClassName,
+		KoordinatorPriority: src.Spec.KoordinatorPriority,
+		SchedulerName:       src.Spec.SchedulerName,
+		Labels:              src.Spec.Labels,
+		Annotations:         src.Spec.Annotations,
+		Patch:               src.Spec.Patch,
+		Paused:              src.Spec.Paused,
+	}
+
+	if data, ok := src.Annotations[restoreAnnotation]; ok {
+		strategy := &ColocationStrategy{}
+		if err := json.Unmarshal([]byte(data), strategy); err != nil {
+			return fmt.Errorf("failed to unmarshal restored v1beta1-only fields: %w", err)
+		}
+		dst.Spec.Strategy = strategy
+		delete(dst.Spec.Annotations, restoreAnnotation)
+		delete(dst.ObjectMeta.Annotations, restoreAnnotation)
+	}
+	return nil
+}
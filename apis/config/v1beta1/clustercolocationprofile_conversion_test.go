@@ -0,0 +1,101 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/koordinator-sh/koordinator/apis/config/v1alpha1"
+	"github.com/koordinator-sh/koordinator/pkg/util"
+)
+
+func Test_ClusterColocationProfile_ConvertTo_ConvertFrom_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  *ClusterColocationProfile
+	}{
+		{
+			name: "without strategy",
+			src: &ClusterColocationProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "profile-a"},
+				Spec: ClusterColocationProfileSpec{
+					QoSClass: "BE",
+					Paused:   false,
+				},
+			},
+		},
+		{
+			name: "with strategy",
+			src: &ClusterColocationProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "profile-b"},
+				Spec: ClusterColocationProfileSpec{
+					QoSClass: "LS",
+					Strategy: &ColocationStrategy{
+						Enable:                     util.BoolPtr(true),
+						CPUReclaimThresholdPercent: util.Int64Ptr(70),
+					},
+				},
+			},
+		},
+		{
+			name: "with strategy and pre-existing annotations",
+			src: &ClusterColocationProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "profile-c",
+					Annotations: map[string]string{"some-other-annotation": "keep-me"},
+				},
+				Spec: ClusterColocationProfileSpec{
+					QoSClass: "LS",
+					Strategy: &ColocationStrategy{
+						Enable: util.BoolPtr(true),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantSrcAnnotations := map[string]string{}
+			for k, v := range tt.src.Annotations {
+				wantSrcAnnotations[k] = v
+			}
+
+			hub := &v1alpha1.ClusterColocationProfile{}
+			assert.NoError(t, tt.src.ConvertTo(hub))
+
+			// ConvertTo writes a restore annotation onto dst; it must not leak back into
+			// the source object's own ObjectMeta.
+			assert.Equal(t, wantSrcAnnotations, tt.src.Annotations)
+
+			back := &ClusterColocationProfile{}
+			assert.NoError(t, back.ConvertFrom(hub))
+
+			// ConvertFrom deletes the restore annotation from dst; it must not strip it
+			// back out of the hub object it was handed.
+			if tt.src.Spec.Strategy != nil {
+				assert.Contains(t, hub.Annotations, restoreAnnotation)
+			}
+
+			assert.Equal(t, tt.src.Spec, back.Spec)
+			assert.Equal(t, wantSrcAnnotations, tt.src.Annotations)
+		})
+	}
+}
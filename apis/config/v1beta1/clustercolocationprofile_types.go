@@ -0,0 +1,105 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ClusterColocationProfileSpec defines the desired state of ClusterColocationProfile. It is
+// a superset of the v1alpha1 spec: existing fields keep their wire format, and new fields
+// (currently just Strategy) can be added here without another breaking bump.
+type ClusterColocationProfileSpec struct {
+	// NamespaceSelector decides whether to inject into the namespace by label selector.
+	// Empty NamespaceSelector matches all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Selector decides whether to inject into the pod by label selector.
+	// Empty Selector matches all pods.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// QoSClass replaces the Pod's `koordinator.sh/qosClass` if the Pod matches the selectors.
+	// +optional
+	QoSClass string `json:"qosClass,omitempty"`
+
+	// Priority replaces the Pod's priority if the Pod matches the selectors.
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+
+	// PriorityClassName replaces the Pod's priority class if the Pod matches the selectors.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// KoordinatorPriority replaces the Pod's priority if the Pod matches the selectors.
+	// +optional
+	KoordinatorPriority *int32 `json:"koordinatorPriority,omitempty"`
+
+	// SchedulerName replaces the Pod's scheduler name if the Pod matches the selectors.
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	// Labels overwrites the Pod's labels by patch merge if the Pod matches the selectors.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations overwrites the Pod's annotations by patch merge if the Pod matches the
+	// selectors.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Patch overwrites a Pod's field if the Pod matches the selectors.
+	// +optional
+	Patch *runtime.RawExtension `json:"patch,omitempty"`
+
+	// Strategy layers a ColocationStrategy override on top of the cluster-wide default for
+	// Pods matched by this profile. New in v1beta1; unset means no override is applied.
+	// +optional
+	Strategy *ColocationStrategy `json:"strategy,omitempty"`
+
+	// Paused indicates whether to suspend the profile.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ClusterColocationProfileStatus defines the observed state of ClusterColocationProfile.
+type ClusterColocationProfileStatus struct {
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterColocationProfile is the Schema for the ClusterColocationProfile API.
+type ClusterColocationProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterColocationProfileSpec   `json:"spec,omitempty"`
+	Status ClusterColocationProfileStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterColocationProfileList contains a list of ClusterColocationProfile.
+type ClusterColocationProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterColocationProfile `json:"items"`
+}
@@ -0,0 +1,68 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Default implements webhook.Defaulter, applied by the mutating webhook on create/update so
+// that an empty Strategy block never shadows the cluster-wide default at reconcile time.
+func (r *ClusterColocationProfile) Default() {
+	if r.Spec.Strategy != nil && IsColocationStrategyEmpty(r.Spec.Strategy) {
+		r.Spec.Strategy = nil
+	}
+}
+
+var _ webhook.Validator = &ClusterColocationProfile{}
+
+// ValidateCreate implements webhook.Validator.
+func (r *ClusterColocationProfile) ValidateCreate() (admission.Warnings, error) {
+	return nil, r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator.
+func (r *ClusterColocationProfile) ValidateUpdate(_ runtime.Object) (admission.Warnings, error) {
+	return nil, r.validate()
+}
+
+// ValidateDelete implements webhook.Validator.
+func (r *ClusterColocationProfile) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (r *ClusterColocationProfile) validate() error {
+	if r.Spec.Strategy != nil && !IsColocationStrategyValid(r.Spec.Strategy) {
+		return fmt.Errorf("spec.strategy is invalid: all non-nil threshold fields must be positive")
+	}
+	if r.Spec.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(r.Spec.NamespaceSelector); err != nil {
+			return fmt.Errorf("spec.namespaceSelector is invalid: %w", err)
+		}
+	}
+	if r.Spec.Selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(r.Spec.Selector); err != nil {
+			return fmt.Errorf("spec.selector is invalid: %w", err)
+		}
+	}
+	return nil
+}
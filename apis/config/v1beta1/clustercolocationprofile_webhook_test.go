@@ -0,0 +1,131 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ClusterColocationProfile_Default(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *ClusterColocationProfile
+		want *ColocationStrategy
+	}{
+		{
+			name: "nil strategy is left alone",
+			in:   &ClusterColocationProfile{},
+			want: nil,
+		},
+		{
+			name: "empty strategy is cleared",
+			in:   &ClusterColocationProfile{Spec: ClusterColocationProfileSpec{Strategy: &ColocationStrategy{}}},
+			want: nil,
+		},
+		{
+			name: "non-empty strategy is kept",
+			in: &ClusterColocationProfile{Spec: ClusterColocationProfileSpec{
+				Strategy: &ColocationStrategy{CPUReclaimThresholdPercent: int64Ptr(60)},
+			}},
+			want: &ColocationStrategy{CPUReclaimThresholdPercent: int64Ptr(60)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.Default()
+			assert.Equal(t, tt.want, tt.in.Spec.Strategy)
+		})
+	}
+}
+
+func Test_ClusterColocationProfile_ValidateCreate_ValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      *ClusterColocationProfile
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			in: &ClusterColocationProfile{Spec: ClusterColocationProfileSpec{
+				Selector:          &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "bar"}},
+				Strategy:          &ColocationStrategy{CPUReclaimThresholdPercent: int64Ptr(60)},
+			}},
+			wantErr: false,
+		},
+		{
+			name:    "no selectors, no strategy",
+			in:      &ClusterColocationProfile{},
+			wantErr: false,
+		},
+		{
+			name: "non-positive strategy threshold",
+			in: &ClusterColocationProfile{Spec: ClusterColocationProfileSpec{
+				Strategy: &ColocationStrategy{CPUReclaimThresholdPercent: int64Ptr(0)},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid selector",
+			in: &ClusterColocationProfile{Spec: ClusterColocationProfileSpec{
+				Selector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "app", Operator: "not-a-real-operator"},
+					},
+				},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid namespaceSelector",
+			in: &ClusterColocationProfile{Spec: ClusterColocationProfileSpec{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "team", Operator: "not-a-real-operator"},
+					},
+				},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.in.ValidateCreate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			_, err = tt.in.ValidateUpdate(nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ClusterColocationProfile_ValidateDelete(t *testing.T) {
+	r := &ClusterColocationProfile{}
+	_, err := r.ValidateDelete()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,55 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ColocationCfg is the v1beta1 wire format of the cluster colocation configuration,
+// mirroring pkg/slo-controller/config.ColocationCfg one field at a time so the two stay
+// convertible without a lossy round-trip.
+type ColocationCfg struct {
+	ColocationStrategy `json:",inline"`
+	NodeConfigs        []NodeColocationCfg `json:"nodeConfigs,omitempty"`
+}
+
+// NodeColocationCfg overlays a ColocationCfg on the nodes matched by NodeSelector.
+type NodeColocationCfg struct {
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// Priority decides which NodeColocationCfg wins when more than one matches the same
+	// node: entries are folded in ascending priority order, so a higher Priority's non-nil
+	// fields overwrite a lower one's. Nil is treated as priority 0. Ties are broken by
+	// selector specificity (the entry matching on more labels/expressions wins), and
+	// further ties by the entry's position in NodeConfigs (the later one wins).
+	// +optional
+	Priority      *int32 `json:"priority,omitempty"`
+	ColocationCfg `json:",inline"`
+}
+
+// ColocationStrategy is the v1beta1 wire format of the colocation strategy thresholds.
+// Field set is unchanged from v1alpha1; the version bump exists so that future per-QoS
+// thresholds and memory bandwidth policies can be added here without another breaking
+// change to v1alpha1 consumers.
+type ColocationStrategy struct {
+	Enable                        *bool    `json:"enable,omitempty"`
+	CPUReclaimThresholdPercent    *int64   `json:"cpuReclaimThresholdPercent,omitempty"`
+	MemoryReclaimThresholdPercent *int64   `json:"memoryReclaimThresholdPercent,omitempty"`
+	DegradeTimeMinutes            *int64   `json:"degradeTimeMinutes,omitempty"`
+	UpdateTimeThresholdSeconds    *int64   `json:"updateTimeThresholdSeconds,omitempty"`
+	ResourceDiffThreshold         *float64 `json:"resourceDiffThreshold,omitempty"`
+}
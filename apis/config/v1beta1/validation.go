@@ -0,0 +1,58 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IsColocationStrategyValid reports whether every set threshold field of strategy is
+// positive. It is the v1beta1 counterpart of
+// pkg/slo-controller/config.IsColocationStrategyValid, used by the validating webhook so
+// that a bad percentage is rejected at `kubectl apply` time instead of silently ignored by
+// the reconciler.
+func IsColocationStrategyValid(strategy *ColocationStrategy) bool {
+	return strategy != nil &&
+		(strategy.CPUReclaimThresholdPercent == nil || *strategy.CPUReclaimThresholdPercent > 0) &&
+		(strategy.MemoryReclaimThresholdPercent == nil || *strategy.MemoryReclaimThresholdPercent > 0) &&
+		(strategy.DegradeTimeMinutes == nil || *strategy.DegradeTimeMinutes > 0) &&
+		(strategy.UpdateTimeThresholdSeconds == nil || *strategy.UpdateTimeThresholdSeconds > 0) &&
+		(strategy.ResourceDiffThreshold == nil || *strategy.ResourceDiffThreshold > 0)
+}
+
+// IsColocationStrategyEmpty reports whether strategy sets no field at all.
+func IsColocationStrategyEmpty(strategy *ColocationStrategy) bool {
+	return strategy == nil || reflect.DeepEqual(strategy, &ColocationStrategy{})
+}
+
+// IsNodeColocationCfgValid reports whether nodeCfg has a valid, non-empty NodeSelector and a
+// non-empty strategy. It is the v1beta1 counterpart of
+// pkg/slo-controller/config.IsNodeColocationCfgValid.
+func IsNodeColocationCfgValid(nodeCfg *NodeColocationCfg) bool {
+	if nodeCfg == nil || nodeCfg.NodeSelector == nil {
+		return false
+	}
+	if nodeCfg.NodeSelector.MatchLabels == nil && len(nodeCfg.NodeSelector.MatchExpressions) == 0 {
+		return false
+	}
+	if _, err := metav1.LabelSelectorAsSelector(nodeCfg.NodeSelector); err != nil {
+		return false
+	}
+	return !IsColocationStrategyEmpty(&nodeCfg.ColocationStrategy)
+}
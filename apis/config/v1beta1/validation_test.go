@@ -0,0 +1,155 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int64Ptr(v int64) *int64       { return &v }
+func float64Ptr(v float64) *float64 { return &v }
+
+func Test_IsColocationStrategyValid(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy *ColocationStrategy
+		want     bool
+	}{
+		{
+			name:     "nil strategy",
+			strategy: nil,
+			want:     false,
+		},
+		{
+			name:     "empty strategy",
+			strategy: &ColocationStrategy{},
+			want:     true,
+		},
+		{
+			name: "all positive thresholds",
+			strategy: &ColocationStrategy{
+				CPUReclaimThresholdPercent:    int64Ptr(60),
+				MemoryReclaimThresholdPercent: int64Ptr(70),
+				DegradeTimeMinutes:            int64Ptr(5),
+				UpdateTimeThresholdSeconds:    int64Ptr(30),
+				ResourceDiffThreshold:         float64Ptr(0.1),
+			},
+			want: true,
+		},
+		{
+			name:     "zero CPUReclaimThresholdPercent",
+			strategy: &ColocationStrategy{CPUReclaimThresholdPercent: int64Ptr(0)},
+			want:     false,
+		},
+		{
+			name:     "negative MemoryReclaimThresholdPercent",
+			strategy: &ColocationStrategy{MemoryReclaimThresholdPercent: int64Ptr(-1)},
+			want:     false,
+		},
+		{
+			name:     "negative ResourceDiffThreshold",
+			strategy: &ColocationStrategy{ResourceDiffThreshold: float64Ptr(-0.1)},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsColocationStrategyValid(tt.strategy))
+		})
+	}
+}
+
+func Test_IsColocationStrategyEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy *ColocationStrategy
+		want     bool
+	}{
+		{name: "nil strategy", strategy: nil, want: true},
+		{name: "empty strategy", strategy: &ColocationStrategy{}, want: true},
+		{name: "one field set", strategy: &ColocationStrategy{CPUReclaimThresholdPercent: int64Ptr(60)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsColocationStrategyEmpty(tt.strategy))
+		})
+	}
+}
+
+func Test_IsNodeColocationCfgValid(t *testing.T) {
+	validStrategy := ColocationStrategy{CPUReclaimThresholdPercent: int64Ptr(60)}
+
+	tests := []struct {
+		name    string
+		nodeCfg *NodeColocationCfg
+		want    bool
+	}{
+		{
+			name:    "nil nodeCfg",
+			nodeCfg: nil,
+			want:    false,
+		},
+		{
+			name:    "nil NodeSelector",
+			nodeCfg: &NodeColocationCfg{ColocationCfg: ColocationCfg{ColocationStrategy: validStrategy}},
+			want:    false,
+		},
+		{
+			name: "empty NodeSelector",
+			nodeCfg: &NodeColocationCfg{
+				NodeSelector:  &metav1.LabelSelector{},
+				ColocationCfg: ColocationCfg{ColocationStrategy: validStrategy},
+			},
+			want: false,
+		},
+		{
+			name: "invalid NodeSelector",
+			nodeCfg: &NodeColocationCfg{
+				NodeSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: "not-a-real-operator"},
+					},
+				},
+				ColocationCfg: ColocationCfg{ColocationStrategy: validStrategy},
+			},
+			want: false,
+		},
+		{
+			name: "empty strategy",
+			nodeCfg: &NodeColocationCfg{
+				NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}},
+			},
+			want: false,
+		},
+		{
+			name: "valid",
+			nodeCfg: &NodeColocationCfg{
+				NodeSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}},
+				ColocationCfg: ColocationCfg{ColocationStrategy: validStrategy},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsNodeColocationCfgValid(tt.nodeCfg))
+		})
+	}
+}
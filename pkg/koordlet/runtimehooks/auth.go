@@ -0,0 +1,130 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// peerAuthUnaryInterceptor rejects calls whose peer identity is not present in the
+// configured CN/SPIFFE allowlists. It is a no-op when no allowlist is configured, so that
+// plain TLS (without RequireClientCert) keeps working unchanged.
+func peerAuthUnaryInterceptor(cfg *Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !cfg.peerAllowlisted() {
+			return handler(ctx, req)
+		}
+		if err := authorizePeer(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("rejected call to %s: %w", info.FullMethod, err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authorizePeer checks the gRPC peer of ctx against the identity allowlists in cfg.
+func authorizePeer(ctx context.Context, cfg *Config) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no peer information in context")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return fmt.Errorf("peer %s did not present a verified client certificate", p.Addr)
+	}
+	cert := tlsInfo.State.VerifiedChains[0][0]
+
+	for _, cn := range cfg.AllowedClientCNs {
+		if cert.Subject.CommonName == cn {
+			return nil
+		}
+	}
+	for _, id := range cfg.AllowedClientSPIFFEIDs {
+		for _, uri := range cert.URIs {
+			if uri.String() == id {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("peer certificate CN=%q has no matching entry in AllowedClientCNs/AllowedClientSPIFFEIDs", cert.Subject.CommonName)
+}
+
+// peerCredListener wraps a unix socket net.Listener and rejects connections from local users
+// whose UID/GID (obtained via SO_PEERCRED) are not in the configured allowlists.
+type peerCredListener struct {
+	net.Listener
+	cfg *Config
+}
+
+func newPeerCredListener(l net.Listener, cfg *Config) net.Listener {
+	if len(cfg.AllowedClientUIDs) == 0 && len(cfg.AllowedClientGIDs) == 0 {
+		return l
+	}
+	return &peerCredListener{Listener: l, cfg: cfg}
+}
+
+func (l *peerCredListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		uid, gid, err := peerCredentials(conn)
+		if err != nil {
+			// Don't propagate this as an Accept error: grpc-go's Server.Serve treats any
+			// non-Temporary error from the listener as fatal and shuts down the whole
+			// server. A single misbehaving or fast-closing client must only cost us this
+			// one connection, not the listener.
+			conn.Close()
+			continue
+		}
+		if !uidAllowed(l.cfg.AllowedClientUIDs, uid) || !gidAllowed(l.cfg.AllowedClientGIDs, gid) {
+			conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
+
+func uidAllowed(allowed []uint32, uid uint32) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func gidAllowed(allowed []uint32, gid uint32) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == gid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUnixListener(t *testing.T) net.Listener {
+	sock := filepath.Join(t.TempDir(), "peercred.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func dialUnix(t *testing.T, l net.Listener) net.Conn {
+	conn, err := net.DialTimeout("unix", l.Addr().String(), time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestPeerCredListener_UIDGIDAllowlist(t *testing.T) {
+	uid := uint32(os.Getuid())
+	gid := uint32(os.Getgid())
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{
+			name:    "no allowlist accepts any peer",
+			cfg:     &Config{},
+			wantErr: false,
+		},
+		{
+			name:    "current uid allowlisted",
+			cfg:     &Config{AllowedClientUIDs: []uint32{uid}},
+			wantErr: false,
+		},
+		{
+			name:    "current gid allowlisted",
+			cfg:     &Config{AllowedClientGIDs: []uint32{gid}},
+			wantErr: false,
+		},
+		{
+			name:    "other uid rejected",
+			cfg:     &Config{AllowedClientUIDs: []uint32{uid + 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := newUnixListener(t)
+			l := newPeerCredListener(raw, tt.cfg)
+
+			var accepted net.Conn
+			var acceptErr error
+			done := make(chan struct{})
+			go func() {
+				accepted, acceptErr = l.Accept()
+				close(done)
+			}()
+
+			client := dialUnix(t, raw)
+
+			if tt.wantErr {
+				// The rejected dial is silently dropped rather than surfacing as an
+				// Accept error; a second dial from the same (still rejected) uid is
+				// dropped too, so Accept keeps waiting.
+				client.Close()
+				client2 := dialUnix(t, raw)
+				select {
+				case <-done:
+					t.Fatal("Accept unexpectedly returned for a rejected peer")
+				case <-time.After(50 * time.Millisecond):
+				}
+				_ = client2
+				return
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("Accept did not return for an allowlisted peer")
+			}
+			require.NoError(t, acceptErr)
+			accepted.Close()
+		})
+	}
+}
+
+// fatalErrListener hands back whatever connection is queued on conns, so a test can feed
+// a non-unix connection on which peerCredentials fails, followed by a real one.
+type fatalErrListener struct {
+	net.Listener
+	conns chan net.Conn
+}
+
+func (l *fatalErrListener) Accept() (net.Conn, error) {
+	return <-l.conns, nil
+}
+
+// TestPeerCredListener_BadConnDoesNotKillAccept guards against a regression where a
+// peerCredentials failure (e.g. a client that disappears mid-handshake) returned a hard
+// error from Accept(), which grpc-go treats as fatal and uses to tear down the whole
+// server instead of just dropping the one bad connection.
+func TestPeerCredListener_BadConnDoesNotKillAccept(t *testing.T) {
+	raw := newUnixListener(t)
+	cfg := &Config{AllowedClientUIDs: []uint32{uint32(os.Getuid())}}
+
+	bad, badPeer := net.Pipe()
+	defer badPeer.Close()
+
+	fake := &fatalErrListener{Listener: raw, conns: make(chan net.Conn, 2)}
+	fake.conns <- bad
+
+	l := newPeerCredListener(fake, cfg)
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := l.Accept()
+		done <- result{conn, err}
+	}()
+
+	// The bad (non-unix) connection must not surface as an Accept() error; Accept should
+	// keep looping for the next connection instead.
+	select {
+	case r := <-done:
+		t.Fatalf("Accept returned before a good connection arrived: conn=%v err=%v", r.conn, r.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	good, err := net.Dial("unix", raw.Addr().String())
+	require.NoError(t, err)
+	defer good.Close()
+	realConn, err := raw.Accept()
+	require.NoError(t, err)
+	fake.conns <- realConn
+
+	select {
+	case r := <-done:
+		assert.NoError(t, r.err)
+		assert.NotNil(t, r.conn)
+	case <-time.After(time.Second):
+		t.Fatal("Accept never returned the good connection")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+// Config holds the options used to launch the RuntimeHook gRPC server.
+type Config struct {
+	// RuntimeHooksMode selects how the CRI runtime reaches this RuntimeHook server:
+	//   - "direct": the existing behavior, listening directly on RuntimeHooksNetwork/Addr.
+	//   - "agent": koordlet dials out to ProxyAddr and serves hook calls tunneled back to it,
+	//     so it never needs an inbound port even in a restricted network namespace.
+	//   - "server": this process runs the tunnel proxy itself (used by koord-manager), fronting
+	//     hook calls for every connected "agent" node.
+	// Empty defaults to "direct".
+	RuntimeHooksMode string
+
+	// RuntimeHooksNetwork is the network the RuntimeHook server listens on, "tcp" or "unix".
+	// Only used in "direct" mode.
+	RuntimeHooksNetwork string
+	// RuntimeHooksAddr is the address (for "tcp") or socket path (for "unix") to listen on.
+	// Only used in "direct" mode.
+	RuntimeHooksAddr string
+
+	// ProxyAddr is the tunnel proxy's address to dial, used in "agent" mode.
+	ProxyAddr string
+	// NodeID identifies this node's tunnel session to the proxy, used in "agent" mode.
+	NodeID string
+	// ProxyListenAddr is the address the tunnel proxy listens on for agent connections, used
+	// in "server" mode.
+	ProxyListenAddr string
+	// ProxyAuthToken is sent alongside this node's registration frame when dialing ProxyAddr
+	// in "agent" mode, and must match ProxyAgentAuthTokens[NodeID] on the "server" side.
+	ProxyAuthToken string
+	// ProxyAgentAuthTokens maps nodeID to the shared secret that node's agent must present in
+	// its registration frame, used in "server" mode. The tunnel registration otherwise trusts
+	// whatever nodeID the connecting client self-reports, letting any process that can reach
+	// ProxyListenAddr register as, or hijack, any node's session; a non-empty map closes that
+	// hole by requiring a token per node. Nil disables the check.
+	ProxyAgentAuthTokens map[string]string
+
+	// ServerCertFile and ServerKeyFile enable TLS on the "tcp" network. Both must be set
+	// together; the certificate is reloaded from disk whenever it changes on disk.
+	ServerCertFile string
+	ServerKeyFile  string
+	// ClientCAFile, if set, is used to verify client certificates presented over TLS.
+	ClientCAFile string
+	// RequireClientCert rejects TLS connections that do not present a certificate signed by
+	// ClientCAFile, turning one-way TLS into mutual TLS.
+	RequireClientCert bool
+	// AllowedClientCNs restricts accepted client certificates to the listed Subject Common
+	// Names. A certificate matching either this list or AllowedClientSPIFFEIDs is accepted;
+	// when both lists are empty, any certificate verified against ClientCAFile is accepted.
+	AllowedClientCNs []string
+	// AllowedClientSPIFFEIDs restricts accepted client certificates to the listed SPIFFE URI
+	// SANs, e.g. "spiffe://cluster.local/ns/kube-system/sa/containerd".
+	AllowedClientSPIFFEIDs []string
+
+	// AllowedClientUIDs and AllowedClientGIDs restrict which local users may dial the "unix"
+	// socket, checked via SO_PEERCRED on accept. Empty means no restriction.
+	AllowedClientUIDs []uint32
+	AllowedClientGIDs []uint32
+}
+
+// tlsEnabled reports whether the server should terminate TLS on its listener.
+func (c *Config) tlsEnabled() bool {
+	return c.ServerCertFile != "" && c.ServerKeyFile != ""
+}
+
+// mTLSEnabled reports whether client certificates should be verified.
+func (c *Config) mTLSEnabled() bool {
+	return c.tlsEnabled() && c.ClientCAFile != ""
+}
+
+// peerAllowlisted reports whether identity allowlisting is configured at all, i.e. whether
+// the unary interceptor needs to inspect the peer on every call.
+func (c *Config) peerAllowlisted() bool {
+	return len(c.AllowedClientCNs) > 0 || len(c.AllowedClientSPIFFEIDs) > 0
+}
+
+const (
+	// RuntimeHooksModeDirect is the default: the server listens directly.
+	RuntimeHooksModeDirect = "direct"
+	// RuntimeHooksModeAgent tunnels out to a central proxy instead of listening directly.
+	RuntimeHooksModeAgent = "agent"
+	// RuntimeHooksModeServer runs the tunnel proxy that agent-mode nodes connect to.
+	RuntimeHooksModeServer = "server"
+)
+
+// mode returns the configured RuntimeHooksMode, defaulting to RuntimeHooksModeDirect.
+func (c *Config) mode() string {
+	if c.RuntimeHooksMode == "" {
+		return RuntimeHooksModeDirect
+	}
+	return c.RuntimeHooksMode
+}
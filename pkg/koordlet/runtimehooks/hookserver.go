@@ -0,0 +1,38 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"context"
+
+	runtimeapi "github.com/koordinator-sh/koordinator/pkg/runtimehooks/api/v1alpha1"
+)
+
+// hookServer implements runtimeapi.RuntimeHookServiceServer. PreRunPodSandboxHook answers
+// with an empty response and does not change the sandbox. The peer authentication
+// interceptor has already run by the time a call reaches here.
+type hookServer struct {
+	runtimeapi.UnimplementedRuntimeHookServiceServer
+}
+
+func newHookServer() *hookServer {
+	return &hookServer{}
+}
+
+func (s *hookServer) PreRunPodSandboxHook(ctx context.Context, req *runtimeapi.PodSandboxHookRequest) (*runtimeapi.PodSandboxHookResponse, error) {
+	return &runtimeapi.PodSandboxHookResponse{}, nil
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+// +build !linux
+
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"fmt"
+	"net"
+)
+
+// peerCredentials is unsupported outside Linux; SO_PEERCRED-based allowlisting is a
+// Linux-only hardening feature since koordlet itself only ships on Linux nodes.
+func peerCredentials(conn net.Conn) (uid, gid uint32, err error) {
+	return 0, 0, fmt.Errorf("unix socket peer credential checks are not supported on this platform")
+}
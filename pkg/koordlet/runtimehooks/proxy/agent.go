@@ -0,0 +1,159 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Dialer opens a connection to target on behalf of an incoming FrameDialRequest. In
+// production this dials the loopback address of koordlet's own direct RuntimeHook listener;
+// tests can substitute anything that satisfies net.Conn.
+type Dialer func(target string) (net.Conn, error)
+
+// AgentConfig configures a single Agent tunnel connection.
+type AgentConfig struct {
+	// Dial opens the local target named by an incoming FrameDialRequest.
+	Dial Dialer
+	// HeartbeatInterval is how often the Agent sends a FrameHeartbeat. A failed heartbeat
+	// send ends Serve; the Server discards heartbeats and does not time out a silent Agent.
+	HeartbeatInterval time.Duration
+	// DrainTimeout bounds how long Serve, once stopCh is closed, waits for in-flight dials to
+	// finish on their own before the tunnel stream is closed out from under them.
+	DrainTimeout time.Duration
+}
+
+// Agent is the node-local half of the tunnel: it owns one Stream dialed out to the Server
+// and serves every FrameDialRequest that arrives on it by dialing Config.Dial.
+type Agent struct {
+	cfg    AgentConfig
+	stream Stream
+	sess   *session
+
+	wg sync.WaitGroup
+}
+
+// NewAgent wraps an already-connected Stream (e.g. a grpc bidi-stream, or one end of
+// NewPipe in tests) as an Agent. Reconnect/backoff across transport drops is the caller's
+// responsibility: call NewAgent again with a freshly dialed Stream and Serve again.
+func NewAgent(stream Stream, cfg AgentConfig) *Agent {
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = 30 * time.Second
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 5 * time.Second
+	}
+	return &Agent{cfg: cfg, stream: stream, sess: newSession(stream)}
+}
+
+// Serve blocks, handling dial requests from the Server until the tunnel stream breaks or
+// stopCh is closed. It returns the error that ended the stream (io.EOF on a clean close by
+// the peer), or, after stopCh is closed, the result of closing the stream.
+func (a *Agent) Serve(stopCh <-chan struct{}) error {
+	heartbeat := time.NewTicker(a.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- a.sess.serve(a.handleDialRequest) }()
+
+	for {
+		select {
+		case err := <-serveErr:
+			return err
+		case <-heartbeat.C:
+			if err := a.stream.Send(&Frame{Type: FrameHeartbeat}); err != nil {
+				return err
+			}
+		case <-stopCh:
+			a.drain()
+			// Closing our half unblocks the peer's Recv. Our own read loop is not
+			// waited for here: on a transport such as the in-memory pipe it only
+			// returns once the peer closes its half too, which Server.Register
+			// does not do, so waiting could deadlock a one-sided shutdown.
+			return a.stream.Close()
+		}
+	}
+}
+
+func (a *Agent) handleDialRequest(id uint64, target string) {
+	localConn, err := a.cfg.Dial(target)
+	conn := a.sess.acceptDial(id, err)
+	if err != nil {
+		klog.Errorf("proxy agent: failed to dial local target %s: %v", target, err)
+		return
+	}
+	if conn == nil {
+		return
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer localConn.Close()
+		defer conn.Close()
+		pump(conn, localConn)
+	}()
+}
+
+// pump copies bytes in both directions between the tunnel-side conn and the local target
+// until either side closes. A FrameClose from the remote only unblocks tunnelConn's Read
+// (see muxConn.closeLocal), so as soon as either copy direction ends we close both halves
+// ourselves; otherwise the local backend connection would leak until it timed out on its
+// own, since the other io.Copy can only return once localConn yields EOF/error.
+func pump(tunnelConn, localConn net.Conn) {
+	var closeOnce sync.Once
+	closeBoth := func() {
+		closeOnce.Do(func() {
+			tunnelConn.Close()
+			localConn.Close()
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(localConn, tunnelConn) //nolint:errcheck // a copy error just means one side hung up
+		closeBoth()
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(tunnelConn, localConn) //nolint:errcheck
+		closeBoth()
+	}()
+	wg.Wait()
+}
+
+// drain waits up to DrainTimeout for in-flight dials spawned by handleDialRequest to finish
+// on their own before Serve closes the underlying stream out from under them.
+func (a *Agent) drain() {
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(a.cfg.DrainTimeout):
+		klog.Warningf("proxy agent: drain timed out after %s with connections still open", a.cfg.DrainTimeout)
+	}
+}
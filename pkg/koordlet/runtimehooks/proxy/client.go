@@ -0,0 +1,50 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+
+	runtimeapi "github.com/koordinator-sh/koordinator/pkg/runtimehooks/api/v1alpha1"
+)
+
+// DialRuntimeHookClient is the consumer-facing entry point onto a node's tunnel: it opens a
+// RuntimeHookServiceClient multiplexed over nodeID's existing Agent session, alongside any
+// other concurrent calls to that node, via Dial. Callers (e.g. koord-manager) use this
+// instead of reaching for Dial directly, since the tunnel carries a real gRPC connection,
+// not a raw net.Conn.
+//
+// The returned grpc.ClientConn must be closed by the caller once done with it; closing it
+// does not tear down nodeID's tunnel session, only this particular gRPC connection over it.
+func (s *Server) DialRuntimeHookClient(ctx context.Context, nodeID string) (runtimeapi.RuntimeHookServiceClient, *grpc.ClientConn, error) {
+	dialer := func(_ context.Context, target string) (net.Conn, error) {
+		return s.Dial(nodeID, target)
+	}
+	conn, err := grpc.DialContext(ctx, nodeID,
+		grpc.WithInsecure(), //nolint:staticcheck // matches the WithInsecure() used elsewhere in this package's tests/direct mode
+		grpc.WithContextDialer(dialer),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial RuntimeHookService on node %s through tunnel: %w", nodeID, err)
+	}
+	return runtimeapi.NewRuntimeHookServiceClient(conn), conn, nil
+}
@@ -0,0 +1,106 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// muxConn adapts one multiplexed DialID on a Stream to a net.Conn, so that callers on
+// either side of the tunnel (the CRI-facing dial on the Server, the loopback dial on the
+// Agent) can use it exactly like a direct connection.
+type muxConn struct {
+	dialID uint64
+	stream Stream
+
+	readBuf   []byte
+	inbox     chan []byte
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func newMuxConn(dialID uint64, stream Stream) *muxConn {
+	return &muxConn{
+		dialID:  dialID,
+		stream:  stream,
+		inbox:   make(chan []byte, 64),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// deliver is called by the Server/Agent read loop for every FrameData addressed to this
+// DialID; it never blocks the loop for long since inbox is buffered.
+func (c *muxConn) deliver(data []byte) {
+	select {
+	case c.inbox <- data:
+	case <-c.closeCh:
+	}
+}
+
+func (c *muxConn) Read(b []byte) (int, error) {
+	for len(c.readBuf) == 0 {
+		select {
+		case data, ok := <-c.inbox:
+			if !ok {
+				return 0, io.EOF
+			}
+			c.readBuf = data
+		case <-c.closeCh:
+			return 0, io.EOF
+		}
+	}
+	n := copy(b, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+func (c *muxConn) Write(b []byte) (int, error) {
+	data := make([]byte, len(b))
+	copy(data, b)
+	if err := c.stream.Send(&Frame{DialID: c.dialID, Type: FrameData, Data: data}); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// closeLocal unblocks any blocked Read/deliver without sending a FrameClose, used when the
+// close was already initiated by the remote end.
+func (c *muxConn) closeLocal() {
+	c.closeOnce.Do(func() { close(c.closeCh) })
+}
+
+func (c *muxConn) Close() error {
+	c.closeLocal()
+	return c.stream.Send(&Frame{DialID: c.dialID, Type: FrameClose})
+}
+
+func (c *muxConn) LocalAddr() net.Addr                { return tunnelAddr(c.dialID) }
+func (c *muxConn) RemoteAddr() net.Addr               { return tunnelAddr(c.dialID) }
+func (c *muxConn) SetDeadline(_ time.Time) error      { return nil }
+func (c *muxConn) SetReadDeadline(_ time.Time) error  { return nil }
+func (c *muxConn) SetWriteDeadline(_ time.Time) error { return nil }
+
+// tunnelAddr is a net.Addr stand-in identifying a muxConn by its DialID; the tunnel has no
+// real socket addresses to report since it is multiplexed over a single outbound stream.
+type tunnelAddr uint64
+
+func (a tunnelAddr) Network() string { return "konnectivity-tunnel" }
+func (a tunnelAddr) String() string  { return "dial-id:" + strconv.FormatUint(uint64(a), 10) }
@@ -0,0 +1,60 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package proxy implements a Konnectivity-style tunnel for pkg/koordlet/runtimehooks: a
+// node-local Agent dials out to a central Server over one long-lived stream, and the Server
+// multiplexes Dial/Data/Close frames across that stream on behalf of many concurrent CRI
+// hook calls, correlated by DialID. This lets koord-manager reach koordlet's RuntimeHook
+// service without koordlet ever opening an inbound port.
+package proxy
+
+// FrameType identifies the purpose of a Frame on the tunnel stream.
+type FrameType int32
+
+const (
+	// FrameDialRequest asks the Agent to open target on behalf of a new logical connection.
+	FrameDialRequest FrameType = iota
+	// FrameDialResponse reports the outcome of a FrameDialRequest.
+	FrameDialResponse
+	// FrameData carries a chunk of bytes for an already-established DialID.
+	FrameData
+	// FrameClose tears down a single DialID; it does not close the tunnel itself.
+	FrameClose
+	// FrameHeartbeat is sent periodically by the Agent to keep an idle tunnel from going
+	// silent; the receiving side discards it.
+	FrameHeartbeat
+)
+
+// Frame is the unit of multiplexing exchanged over a Stream. Target is only meaningful on
+// FrameDialRequest; Error is only meaningful on FrameDialResponse. AuthToken is only
+// meaningful on the very first FrameDialRequest an Agent sends, which doubles as its
+// registration with the Server; see ListenAndServe.
+type Frame struct {
+	DialID    uint64
+	Type      FrameType
+	Target    string
+	Data      []byte
+	Error     string
+	AuthToken string
+}
+
+// Stream is the transport a tunnel runs over: a grpc bidi-stream in production, or an
+// in-memory pipe in tests. Recv returns io.EOF once the peer has cleanly closed its side.
+type Stream interface {
+	Send(*Frame) error
+	Recv() (*Frame, error)
+	Close() error
+}
@@ -0,0 +1,151 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// netStream carries Frames as length-prefixed JSON over a single net.Conn, which is the
+// transport DialAgent/ListenAndServe use for the "agent"/"server" RuntimeHooksMode.
+// It plays the same multiplexing role a gRPC bidi-stream would; swapping this out for one
+// is a transport-layer change only, since Stream is the only interface the rest of this
+// package depends on.
+type netStream struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	writeMu sync.Mutex
+}
+
+func newNetStream(conn net.Conn) Stream {
+	return &netStream{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (s *netStream) Send(f *Frame) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to marshal frame: %w", err)
+	}
+	if len(data) > 1<<24 {
+		return fmt.Errorf("frame of %d bytes exceeds the 16MiB limit", len(data))
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+	if _, err := s.conn.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = s.conn.Write(data)
+	return err
+}
+
+func (s *netStream) Recv() (*Frame, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	data := make([]byte, size)
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		return nil, err
+	}
+	frame := &Frame{}
+	if err := json.Unmarshal(data, frame); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal frame: %w", err)
+	}
+	return frame, nil
+}
+
+func (s *netStream) Close() error {
+	return s.conn.Close()
+}
+
+// DialAgent dials addr and registers as nodeID's tunnel, per "agent" RuntimeHooksMode. It
+// returns as soon as the registration frame is sent; the caller runs the returned Agent's
+// Serve and closes the returned conn once Serve ends.
+// authToken is sent alongside the registration and must match the Server's
+// ProxyAgentAuthTokens[nodeID] when the Server has that check enabled.
+func DialAgent(addr, nodeID, authToken string, cfg AgentConfig) (*Agent, net.Conn, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial tunnel proxy %s: %w", addr, err)
+	}
+	stream := newNetStream(conn)
+	if err := stream.Send(&Frame{Type: FrameDialRequest, Target: nodeID, AuthToken: authToken}); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to register as %s with tunnel proxy: %w", nodeID, err)
+	}
+	return NewAgent(stream, cfg), conn, nil
+}
+
+// ListenAndServe accepts agent connections on addr, reads each one's registration frame to
+// learn its nodeID, and hands it off to server.Register, per "server" RuntimeHooksMode. It
+// blocks until stopCh is closed.
+//
+// The registration otherwise trusts whatever nodeID the connecting client self-reports over
+// a plaintext connection, letting any process that can reach addr register as, or hijack,
+// any node's session. When agentAuthTokens is non-empty, a registration is accepted only if
+// its AuthToken matches agentAuthTokens[nodeID]; a nil or empty map leaves registration
+// unauthenticated, for backwards compatibility and tests.
+func ListenAndServe(addr string, server *Server, agentAuthTokens map[string]string, stopCh <-chan struct{}) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-stopCh
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-stopCh:
+				return nil
+			default:
+				return err
+			}
+		}
+		go func() {
+			stream := newNetStream(conn)
+			reg, err := stream.Recv()
+			if err != nil || reg.Type != FrameDialRequest {
+				conn.Close()
+				return
+			}
+			if len(agentAuthTokens) > 0 && reg.AuthToken != agentAuthTokens[reg.Target] {
+				klog.Errorf("proxy: rejecting registration for node %s: auth token mismatch", reg.Target)
+				conn.Close()
+				return
+			}
+			server.Register(reg.Target, stream, stopCh) //nolint:errcheck // logged inside Register
+		}()
+	}
+}
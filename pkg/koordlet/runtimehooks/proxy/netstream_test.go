@@ -0,0 +1,132 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_DialAgent_ListenAndServe_RoundTrip exercises the production transport end to end over
+// a real net.Listen("tcp", ...) pair, rather than the in-memory pipeStream every other test in
+// this package drives: the length-prefixed JSON framing, the 16MiB frame-size check, and the
+// registration handshake in ListenAndServe all run for real here.
+func Test_DialAgent_ListenAndServe_RoundTrip(t *testing.T) {
+	echoAddr := startEchoListener(t)
+
+	proxyListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxyAddr := proxyListener.Addr().String()
+	require.NoError(t, proxyListener.Close())
+
+	server := NewServer()
+	stop := make(chan struct{})
+	listenDone := make(chan error, 1)
+	go func() { listenDone <- ListenAndServe(proxyAddr, server, nil, stop) }()
+
+	agent, conn, err := DialAgent(proxyAddr, "node-1", "", AgentConfig{
+		Dial: func(target string) (net.Conn, error) { return net.Dial("tcp", target) },
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	agentStop := make(chan struct{})
+	agentDone := make(chan error, 1)
+	go func() { agentDone <- agent.Serve(agentStop) }()
+
+	require.Eventually(t, func() bool { return server.Connected("node-1") }, 2*time.Second, 10*time.Millisecond)
+
+	tunnelConn, err := server.Dial("node-1", echoAddr)
+	require.NoError(t, err)
+
+	msg := []byte("hello over a real tcp tunnel")
+	_, err = tunnelConn.Write(msg)
+	require.NoError(t, err)
+
+	buf := make([]byte, len(msg))
+	_, err = io.ReadFull(tunnelConn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, msg, buf)
+
+	assert.NoError(t, tunnelConn.Close())
+
+	close(agentStop)
+	assert.NoError(t, <-agentDone)
+	close(stop)
+	assert.NoError(t, <-listenDone)
+}
+
+// Test_ListenAndServe_RejectsRegistrationWithBadAuthToken guards against the tunnel
+// registration trusting whatever nodeID a connecting client self-reports: when
+// agentAuthTokens is configured, a registration with a missing or wrong token must not be
+// admitted as that node's session.
+func Test_ListenAndServe_RejectsRegistrationWithBadAuthToken(t *testing.T) {
+	proxyListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxyAddr := proxyListener.Addr().String()
+	require.NoError(t, proxyListener.Close())
+
+	server := NewServer()
+	stop := make(chan struct{})
+	listenDone := make(chan error, 1)
+	go func() { listenDone <- ListenAndServe(proxyAddr, server, map[string]string{"node-1": "s3cr3t"}, stop) }()
+	defer func() { close(stop); <-listenDone }()
+
+	agent, conn, err := DialAgent(proxyAddr, "node-1", "wrong-token", AgentConfig{
+		Dial: func(target string) (net.Conn, error) { return net.Dial("tcp", target) },
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	agentStop := make(chan struct{})
+	defer close(agentStop)
+	go agent.Serve(agentStop) //nolint:errcheck
+
+	require.Never(t, func() bool { return server.Connected("node-1") }, 300*time.Millisecond, 20*time.Millisecond)
+}
+
+// Test_ListenAndServe_AcceptsRegistrationWithGoodAuthToken is the positive counterpart: the
+// correct per-node token must still be admitted.
+func Test_ListenAndServe_AcceptsRegistrationWithGoodAuthToken(t *testing.T) {
+	proxyListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxyAddr := proxyListener.Addr().String()
+	require.NoError(t, proxyListener.Close())
+
+	server := NewServer()
+	stop := make(chan struct{})
+	listenDone := make(chan error, 1)
+	go func() { listenDone <- ListenAndServe(proxyAddr, server, map[string]string{"node-1": "s3cr3t"}, stop) }()
+	defer func() { close(stop); <-listenDone }()
+
+	agent, conn, err := DialAgent(proxyAddr, "node-1", "s3cr3t", AgentConfig{
+		Dial: func(target string) (net.Conn, error) { return net.Dial("tcp", target) },
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	agentStop := make(chan struct{})
+	defer close(agentStop)
+	go agent.Serve(agentStop) //nolint:errcheck
+
+	require.Eventually(t, func() bool { return server.Connected("node-1") }, 2*time.Second, 10*time.Millisecond)
+}
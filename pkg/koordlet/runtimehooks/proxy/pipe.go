@@ -0,0 +1,69 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"sync"
+)
+
+// pipeStream is an in-memory Stream backed by a pair of channels, used to connect an Agent
+// directly to a Server in unit tests without a real network listener.
+type pipeStream struct {
+	out chan *Frame
+	in  chan *Frame
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewPipe returns a pair of connected Streams: frames sent on a are received on b and
+// vice versa. Useful for testing the Server/Agent multiplexing logic end to end.
+func NewPipe() (a, b Stream) {
+	c1 := make(chan *Frame, 16)
+	c2 := make(chan *Frame, 16)
+	return &pipeStream{out: c1, in: c2}, &pipeStream{out: c2, in: c1}
+}
+
+func (p *pipeStream) Send(f *Frame) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return io.ErrClosedPipe
+	}
+	p.out <- f
+	return nil
+}
+
+func (p *pipeStream) Recv() (*Frame, error) {
+	f, ok := <-p.in
+	if !ok {
+		return nil, io.EOF
+	}
+	return f, nil
+}
+
+func (p *pipeStream) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.out)
+	return nil
+}
@@ -0,0 +1,225 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	runtimeapi "github.com/koordinator-sh/koordinator/pkg/runtimehooks/api/v1alpha1"
+)
+
+// stubHookServer is a minimal runtimeapi.RuntimeHookServiceServer standing in for koordlet's
+// real hookServer, just so DialRuntimeHookClient has something to call through the tunnel.
+type stubHookServer struct {
+	runtimeapi.UnimplementedRuntimeHookServiceServer
+}
+
+func (s *stubHookServer) PreRunPodSandboxHook(_ context.Context, _ *runtimeapi.PodSandboxHookRequest) (*runtimeapi.PodSandboxHookResponse, error) {
+	return &runtimeapi.PodSandboxHookResponse{}, nil
+}
+
+// startEchoListener starts a tiny TCP echo server and returns its address, to stand in for
+// koordlet's real direct RuntimeHook listener that the Agent dials on FrameDialRequest.
+func startEchoListener(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn) //nolint:errcheck
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func Test_Server_Agent_Tunnel_RoundTrip(t *testing.T) {
+	echoAddr := startEchoListener(t)
+
+	serverSide, agentSide := NewPipe()
+
+	server := NewServer()
+	serverStop := make(chan struct{})
+	registerDone := make(chan error, 1)
+	go func() { registerDone <- server.Register("node-1", serverSide, serverStop) }()
+
+	agent := NewAgent(agentSide, AgentConfig{
+		Dial: func(target string) (net.Conn, error) { return net.Dial("tcp", target) },
+	})
+	agentStop := make(chan struct{})
+	agentDone := make(chan error, 1)
+	go func() { agentDone <- agent.Serve(agentStop) }()
+
+	// Give both sides' serve loops a moment to start.
+	time.Sleep(50 * time.Millisecond)
+
+	require.True(t, server.Connected("node-1"))
+
+	conn, err := server.Dial("node-1", echoAddr)
+	require.NoError(t, err)
+
+	msg := []byte("hello through the tunnel")
+	_, err = conn.Write(msg)
+	require.NoError(t, err)
+
+	buf := make([]byte, len(msg))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, msg, buf)
+
+	assert.NoError(t, conn.Close())
+
+	close(agentStop)
+	assert.NoError(t, <-agentDone)
+	close(serverStop)
+	<-registerDone
+}
+
+// trackedConn wraps a net.Conn and reports on closed when Close is called, so tests can
+// observe that the Agent side actually tears down the backend connection.
+type trackedConn struct {
+	net.Conn
+	closed chan struct{}
+}
+
+func (c *trackedConn) Close() error {
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	return c.Conn.Close()
+}
+
+// Test_Server_Agent_Tunnel_ClosesBackendOnRemoteClose guards against a regression where the
+// Agent only closed the backend connection dialed for a tunneled call once *both* directions
+// of its io.Copy pump had already returned on their own. Since the local->tunnel direction
+// only returns once the backend itself yields EOF, a remote-initiated close (the normal
+// per-RPC lifecycle) used to leak the backend connection until it timed out independently.
+func Test_Server_Agent_Tunnel_ClosesBackendOnRemoteClose(t *testing.T) {
+	backendClosed := make(chan struct{})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		tc := &trackedConn{Conn: conn, closed: backendClosed}
+		// Never writes EOF on its own: the only way this goroutine's io.Copy(tc, tc)
+		// below returns is if the Agent closes its end of this connection.
+		io.Copy(tc, tc) //nolint:errcheck
+		// io.Copy returned, so the Agent has closed its end; record that on backendClosed.
+		tc.Close()
+	}()
+
+	serverSide, agentSide := NewPipe()
+
+	server := NewServer()
+	serverStop := make(chan struct{})
+	registerDone := make(chan error, 1)
+	go func() { registerDone <- server.Register("node-1", serverSide, serverStop) }()
+	defer func() { close(serverStop); <-registerDone }()
+
+	agent := NewAgent(agentSide, AgentConfig{
+		Dial: func(target string) (net.Conn, error) { return net.Dial("tcp", target) },
+	})
+	agentStop := make(chan struct{})
+	agentDone := make(chan error, 1)
+	go func() { agentDone <- agent.Serve(agentStop) }()
+	defer func() { close(agentStop); <-agentDone }()
+
+	time.Sleep(50 * time.Millisecond)
+	require.True(t, server.Connected("node-1"))
+
+	conn, err := server.Dial("node-1", l.Addr().String())
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Close())
+
+	select {
+	case <-backendClosed:
+	case <-time.After(time.Second):
+		t.Fatal("Agent did not close the backend connection after the remote closed its end")
+	}
+}
+
+// Test_Server_DialRuntimeHookClient_RoundTrip exercises the consumer-facing entry point a
+// client like koord-manager would use: dialing a real RuntimeHookServiceClient through the
+// tunnel, rather than the raw net.Conn that server.Dial alone returns.
+func Test_Server_DialRuntimeHookClient_RoundTrip(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	grpcServer := grpc.NewServer()
+	runtimeapi.RegisterRuntimeHookServiceServer(grpcServer, &stubHookServer{})
+	go grpcServer.Serve(l) //nolint:errcheck
+	defer grpcServer.Stop()
+
+	serverSide, agentSide := NewPipe()
+
+	server := NewServer()
+	serverStop := make(chan struct{})
+	registerDone := make(chan error, 1)
+	go func() { registerDone <- server.Register("node-1", serverSide, serverStop) }()
+	defer func() { close(serverStop); <-registerDone }()
+
+	agent := NewAgent(agentSide, AgentConfig{
+		// Mirrors production "agent" mode: Dial ignores the requested target and always
+		// connects to this node's own loopback RuntimeHook listener.
+		Dial: func(_ string) (net.Conn, error) { return net.Dial("tcp", l.Addr().String()) },
+	})
+	agentStop := make(chan struct{})
+	agentDone := make(chan error, 1)
+	go func() { agentDone <- agent.Serve(agentStop) }()
+	defer func() { close(agentStop); <-agentDone }()
+
+	time.Sleep(50 * time.Millisecond)
+	require.True(t, server.Connected("node-1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, conn, err := server.DialRuntimeHookClient(ctx, "node-1")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = client.PreRunPodSandboxHook(ctx, &runtimeapi.PodSandboxHookRequest{})
+	assert.NoError(t, err)
+}
+
+func Test_Server_Dial_UnregisteredNode(t *testing.T) {
+	server := NewServer()
+	_, err := server.Dial("does-not-exist", "127.0.0.1:0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// Server runs on koord-manager: it accepts one long-lived tunnel Stream per node Agent and
+// lets the RuntimeHook gRPC client on that node's behalf be dialed by nodeID, without ever
+// opening a port on the node itself.
+type Server struct {
+	mu       sync.RWMutex
+	sessions map[string]*session
+}
+
+// NewServer creates an empty Server; call Register once per Agent connection.
+func NewServer() *Server {
+	return &Server{sessions: map[string]*session{}}
+}
+
+// Register adopts a newly connected Agent's tunnel stream under nodeID and serves it until
+// the stream breaks or stopCh is closed, at which point the session is unregistered and any
+// still-open Dial'd connections are drained. Register blocks until the stream ends, so
+// callers run it in its own goroutine per incoming connection.
+func (s *Server) Register(nodeID string, stream Stream, stopCh <-chan struct{}) error {
+	sess := newSession(stream)
+
+	s.mu.Lock()
+	if _, exists := s.sessions[nodeID]; exists {
+		s.mu.Unlock()
+		klog.Errorf("proxy: rejecting tunnel for agent %s: already registered", nodeID)
+		return fmt.Errorf("agent %s is already registered", nodeID)
+	}
+	s.sessions[nodeID] = sess
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, nodeID)
+		s.mu.Unlock()
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- sess.serve(nil) }()
+
+	select {
+	case err := <-done:
+		klog.Infof("proxy: agent %s tunnel closed: %v", nodeID, err)
+		return err
+	case <-stopCh:
+		_ = stream.Close()
+		<-done
+		return nil
+	}
+}
+
+// Dial opens target on the node identified by nodeID, multiplexed over that node's existing
+// tunnel stream, and returns a net.Conn-like connection to it. It fails fast if the node has
+// no registered tunnel.
+func (s *Server) Dial(nodeID, target string) (*muxConn, error) {
+	s.mu.RLock()
+	sess, ok := s.sessions[nodeID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no tunnel registered for node %s", nodeID)
+	}
+	return sess.dial(target)
+}
+
+// Connected reports whether nodeID currently has a live tunnel registered.
+func (s *Server) Connected(nodeID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.sessions[nodeID]
+	return ok
+}
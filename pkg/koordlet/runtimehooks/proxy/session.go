@@ -0,0 +1,159 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"k8s.io/klog/v2"
+)
+
+// session multiplexes a single Stream (one Agent's tunnel connection) across many
+// concurrent DialIDs. It is shared by Server (dialing out to the Agent) and Agent (serving
+// DialRequests coming from the Server), which is why it lives in its own file.
+type session struct {
+	stream Stream
+	nextID uint64
+
+	mu    sync.Mutex
+	conns map[uint64]*muxConn
+	// pendingDials holds dial responses awaited by the side that initiated the DialRequest.
+	pendingDials map[uint64]chan *Frame
+}
+
+func newSession(stream Stream) *session {
+	return &session{
+		stream:       stream,
+		conns:        map[uint64]*muxConn{},
+		pendingDials: map[uint64]chan *Frame{},
+	}
+}
+
+// dial sends a FrameDialRequest for target and blocks for the matching FrameDialResponse.
+func (s *session) dial(target string) (*muxConn, error) {
+	id := atomic.AddUint64(&s.nextID, 1)
+	respCh := make(chan *Frame, 1)
+
+	s.mu.Lock()
+	s.pendingDials[id] = respCh
+	s.mu.Unlock()
+
+	if err := s.stream.Send(&Frame{DialID: id, Type: FrameDialRequest, Target: target}); err != nil {
+		s.mu.Lock()
+		delete(s.pendingDials, id)
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	resp, ok := <-respCh
+	if !ok || resp == nil {
+		return nil, fmt.Errorf("tunnel closed before dial to %s completed", target)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("remote dial to %s failed: %s", target, resp.Error)
+	}
+
+	conn := newMuxConn(id, s.stream)
+	s.mu.Lock()
+	s.conns[id] = conn
+	s.mu.Unlock()
+	return conn, nil
+}
+
+// serve runs the read loop for stream until it returns an error (including io.EOF), routing
+// each frame to the right muxConn, pending dial, or to onDialRequest for new inbound dials.
+// It returns when the stream is no longer readable, after draining in-flight connections.
+func (s *session) serve(onDialRequest func(id uint64, target string)) error {
+	for {
+		frame, err := s.stream.Recv()
+		if err != nil {
+			s.drain()
+			return err
+		}
+		switch frame.Type {
+		case FrameDialRequest:
+			if onDialRequest != nil {
+				onDialRequest(frame.DialID, frame.Target)
+			}
+		case FrameDialResponse:
+			s.mu.Lock()
+			ch, ok := s.pendingDials[frame.DialID]
+			delete(s.pendingDials, frame.DialID)
+			s.mu.Unlock()
+			if ok {
+				ch <- frame
+			}
+		case FrameData:
+			s.mu.Lock()
+			conn, ok := s.conns[frame.DialID]
+			s.mu.Unlock()
+			if ok {
+				conn.deliver(frame.Data)
+			}
+		case FrameClose:
+			s.mu.Lock()
+			conn, ok := s.conns[frame.DialID]
+			delete(s.conns, frame.DialID)
+			s.mu.Unlock()
+			if ok {
+				conn.closeLocal()
+			}
+		case FrameHeartbeat:
+			// no-op: heartbeats carry no payload and no peer liveness is tracked here.
+		default:
+			klog.Warningf("proxy: ignoring frame of unknown type %d", frame.Type)
+		}
+	}
+}
+
+// acceptDial completes a FrameDialRequest the local side decided to serve, registering a
+// muxConn for subsequent FrameData/FrameClose frames and replying with the outcome.
+func (s *session) acceptDial(id uint64, dialErr error) *muxConn {
+	resp := &Frame{DialID: id, Type: FrameDialResponse}
+	if dialErr != nil {
+		resp.Error = dialErr.Error()
+	}
+	if err := s.stream.Send(resp); err != nil {
+		klog.Errorf("proxy: failed to ack dial %d: %v", id, err)
+		return nil
+	}
+	if dialErr != nil {
+		return nil
+	}
+	conn := newMuxConn(id, s.stream)
+	s.mu.Lock()
+	s.conns[id] = conn
+	s.mu.Unlock()
+	return conn
+}
+
+// drain closes every still-open muxConn so that blocked Read calls return io.EOF instead of
+// hanging forever once the underlying stream has gone away.
+func (s *session) drain() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, conn := range s.conns {
+		conn.closeLocal()
+		delete(s.conns, id)
+	}
+	for id, ch := range s.pendingDials {
+		close(ch)
+		delete(s.pendingDials, id)
+	}
+}
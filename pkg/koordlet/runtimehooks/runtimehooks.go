@@ -0,0 +1,236 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"k8s.io/klog/v2"
+
+	"github.com/koordinator-sh/koordinator/pkg/koordlet/runtimehooks/proxy"
+	runtimeapi "github.com/koordinator-sh/koordinator/pkg/runtimehooks/api/v1alpha1"
+)
+
+const (
+	// tunnelBackoffInitial is the delay before the first reconnect attempt after the tunnel
+	// to ProxyAddr drops.
+	tunnelBackoffInitial = 1 * time.Second
+	// tunnelBackoffMax caps how long runAgent waits between reconnect attempts. It also
+	// doubles as the "was this connection healthy" threshold: a tunnel that stayed up at
+	// least this long resets the backoff back to tunnelBackoffInitial on its next drop.
+	tunnelBackoffMax = 30 * time.Second
+)
+
+// RuntimeHook serves the RuntimeHookService gRPC API that the container runtime calls into
+// at well-defined points of the container lifecycle (e.g. PreRunPodSandboxHook).
+type RuntimeHook interface {
+	Run(stopCh <-chan struct{}) error
+}
+
+type runtimeHook struct {
+	config     *Config
+	grpcServer *grpc.Server
+}
+
+// NewRuntimeHook creates a RuntimeHook server from the given Config. It does not start
+// listening until Run is called.
+func NewRuntimeHook(config *Config) (RuntimeHook, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config must not be nil")
+	}
+	switch config.mode() {
+	case RuntimeHooksModeDirect:
+		switch config.RuntimeHooksNetwork {
+		case "tcp", "unix":
+		default:
+			return nil, fmt.Errorf("unsupported RuntimeHooksNetwork %q, must be \"tcp\" or \"unix\"", config.RuntimeHooksNetwork)
+		}
+	case RuntimeHooksModeAgent:
+		if config.ProxyAddr == "" || config.NodeID == "" {
+			return nil, fmt.Errorf("agent mode requires both ProxyAddr and NodeID")
+		}
+	case RuntimeHooksModeServer:
+		if config.ProxyListenAddr == "" {
+			return nil, fmt.Errorf("server mode requires ProxyListenAddr")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported RuntimeHooksMode %q", config.RuntimeHooksMode)
+	}
+	return &runtimeHook{config: config}, nil
+}
+
+// Run starts serving the RuntimeHookService until stopCh is closed.
+func (r *runtimeHook) Run(stopCh <-chan struct{}) error {
+	switch r.config.mode() {
+	case RuntimeHooksModeAgent:
+		return r.runAgent(stopCh)
+	case RuntimeHooksModeServer:
+		if len(r.config.ProxyAgentAuthTokens) == 0 {
+			klog.Warningf("ProxyAgentAuthTokens not configured; any process that can reach %s can register as, or hijack, any node's tunnel", r.config.ProxyListenAddr)
+		}
+		return proxy.ListenAndServe(r.config.ProxyListenAddr, proxy.NewServer(), r.config.ProxyAgentAuthTokens, stopCh)
+	default:
+		return r.runDirect(stopCh)
+	}
+}
+
+// runDirect is the original behavior: it listens on RuntimeHooksNetwork/Addr itself.
+func (r *runtimeHook) runDirect(stopCh <-chan struct{}) error {
+	listener, err := r.listen(stopCh)
+	if err != nil {
+		return fmt.Errorf("failed to create listener: %w", err)
+	}
+	return r.serve(listener, stopCh)
+}
+
+// runAgent starts the RuntimeHookService on a loopback-only listener, then tunnels it to
+// ProxyAddr so that koord-manager can reach it without this node exposing any port.
+func (r *runtimeHook) runAgent(stopCh <-chan struct{}) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to create loopback listener: %w", err)
+	}
+	localAddr := listener.Addr().String()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- r.serve(listener, stopCh) }()
+
+	tunnelErr := make(chan error, 1)
+	go func() { tunnelErr <- r.runTunnelWithBackoff(localAddr, stopCh) }()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case err := <-tunnelErr:
+		return err
+	case <-stopCh:
+		return nil
+	}
+}
+
+// runTunnelWithBackoff keeps the tunnel to ProxyAddr up for as long as stopCh is open,
+// redialing with exponential backoff whenever it drops (proxy restart, network blip, idle
+// reset, ...). It only returns once stopCh is closed; a dropped tunnel is never treated as
+// fatal to the RuntimeHook process.
+func (r *runtimeHook) runTunnelWithBackoff(localAddr string, stopCh <-chan struct{}) error {
+	backoff := tunnelBackoffInitial
+	for {
+		connectedAt := time.Now()
+		err := r.runTunnelOnce(localAddr, stopCh)
+
+		select {
+		case <-stopCh:
+			return nil
+		default:
+		}
+
+		if time.Since(connectedAt) >= tunnelBackoffMax {
+			backoff = tunnelBackoffInitial
+		}
+		klog.Errorf("proxy agent: tunnel to %s disconnected, reconnecting in %s: %v", r.config.ProxyAddr, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-stopCh:
+			return nil
+		}
+		if backoff *= 2; backoff > tunnelBackoffMax {
+			backoff = tunnelBackoffMax
+		}
+	}
+}
+
+// runTunnelOnce dials ProxyAddr once and serves the tunnel until it breaks or stopCh closes.
+func (r *runtimeHook) runTunnelOnce(localAddr string, stopCh <-chan struct{}) error {
+	agent, conn, err := proxy.DialAgent(r.config.ProxyAddr, r.config.NodeID, r.config.ProxyAuthToken, proxy.AgentConfig{
+		Dial: func(string) (net.Conn, error) { return net.Dial("tcp", localAddr) },
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to tunnel proxy: %w", err)
+	}
+	defer conn.Close()
+	return agent.Serve(stopCh)
+}
+
+// serve runs the gRPC server over an already-created listener until it errors or stopCh
+// closes, gracefully draining in-flight calls on the latter.
+func (r *runtimeHook) serve(listener net.Listener, stopCh <-chan struct{}) error {
+	serverOpts, err := r.serverOptions(stopCh)
+	if err != nil {
+		return fmt.Errorf("failed to build gRPC server options: %w", err)
+	}
+
+	r.grpcServer = grpc.NewServer(serverOpts...)
+	runtimeapi.RegisterRuntimeHookServiceServer(r.grpcServer, newHookServer())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- r.grpcServer.Serve(listener)
+	}()
+
+	go func() {
+		<-stopCh
+		r.grpcServer.GracefulStop()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stopCh:
+		return nil
+	}
+}
+
+// listen creates the network listener for the configured mode, wrapping it with
+// SO_PEERCRED allowlisting when running over a unix socket.
+func (r *runtimeHook) listen(stopCh <-chan struct{}) (net.Listener, error) {
+	if r.config.RuntimeHooksNetwork == "unix" {
+		if err := os.Remove(r.config.RuntimeHooksAddr); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", r.config.RuntimeHooksAddr, err)
+		}
+		l, err := net.Listen("unix", r.config.RuntimeHooksAddr)
+		if err != nil {
+			return nil, err
+		}
+		return newPeerCredListener(l, r.config), nil
+	}
+	return net.Listen("tcp", r.config.RuntimeHooksAddr)
+}
+
+// serverOptions builds the grpc.ServerOption list: TLS/mTLS credentials for "tcp" and the
+// peer-identity allowlist interceptor, when configured.
+func (r *runtimeHook) serverOptions(stopCh <-chan struct{}) ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+
+	if r.config.RuntimeHooksNetwork == "tcp" && r.config.tlsEnabled() {
+		tlsConfig, err := buildServerTLSConfig(r.config, stopCh)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else if r.config.peerAllowlisted() {
+		klog.Warningf("AllowedClientCNs/AllowedClientSPIFFEIDs configured without TLS; every call will be rejected since no client certificate can be verified")
+	}
+
+	opts = append(opts, grpc.UnaryInterceptor(peerAuthUnaryInterceptor(r.config)))
+	return opts, nil
+}
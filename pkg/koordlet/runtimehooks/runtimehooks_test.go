@@ -17,10 +17,13 @@
 package runtimehooks
 
 import (
+	"net"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_runtimeHook_Run(t *testing.T) {
@@ -67,3 +70,54 @@ func Test_runtimeHook_Run(t *testing.T) {
 		})
 	}
 }
+
+// Test_runtimeHook_RunAgent_ReconnectsAfterDrop guards against a regression where "agent"
+// mode dialed the tunnel proxy exactly once and gave up for good the moment that connection
+// broke. A fake proxy here drops the first connection immediately after accepting it; the
+// RuntimeHook must back off and redial rather than letting Run return.
+func Test_runtimeHook_RunAgent_ReconnectsAfterDrop(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			if i == 0 {
+				conn.Close()
+				continue
+			}
+			accepted <- conn
+		}
+	}()
+
+	r, err := NewRuntimeHook(&Config{
+		RuntimeHooksMode: RuntimeHooksModeAgent,
+		ProxyAddr:        l.Addr().String(),
+		NodeID:           "node-x",
+	})
+	require.NoError(t, err)
+
+	stop := make(chan struct{})
+	runDone := make(chan error, 1)
+	go func() { runDone <- r.Run(stop) }()
+
+	select {
+	case conn := <-accepted:
+		conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("RuntimeHook did not reconnect to the tunnel proxy after the first connection dropped")
+	}
+
+	close(stop)
+	select {
+	case err := <-runDone:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after stopCh was closed")
+	}
+}
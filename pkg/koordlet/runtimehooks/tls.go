@@ -0,0 +1,141 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// certReloadInterval is how often the on-disk certificate/key pair is checked for changes.
+const certReloadInterval = 10 * time.Second
+
+// reloadableCertificate watches a certificate/key pair on disk and serves the latest parsed
+// pair to the TLS stack via GetCertificate, so that rotating the files does not require
+// restarting the RuntimeHook server.
+type reloadableCertificate struct {
+	certFile string
+	keyFile  string
+
+	current atomic.Value // *tls.Certificate
+	modTime time.Time
+	stopCh  chan struct{}
+}
+
+func newReloadableCertificate(certFile, keyFile string) (*reloadableCertificate, error) {
+	r := &reloadableCertificate{
+		certFile: certFile,
+		keyFile:  keyFile,
+		stopCh:   make(chan struct{}),
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reloadableCertificate) reload() error {
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat server cert %s: %w", r.certFile, err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server cert/key pair: %w", err)
+	}
+	r.current.Store(&cert)
+	r.modTime = info.ModTime()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *reloadableCertificate) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.current.Load().(*tls.Certificate), nil
+}
+
+// watch checks the certificate file's mtime every interval and reloads the certificate/key
+// pair when it is newer than the last load, until stopped. A change to the key file alone
+// is not detected. interval is a parameter (rather than always certReloadInterval) so tests
+// can exercise the reload loop without waiting out the real interval.
+func (r *reloadableCertificate) watch(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(r.certFile)
+			if err != nil {
+				klog.Errorf("failed to stat server cert %s for hot-reload: %v", r.certFile, err)
+				continue
+			}
+			if !info.ModTime().After(r.modTime) {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				klog.Errorf("failed to hot-reload server cert/key pair: %v", err)
+				continue
+			}
+			klog.Infof("reloaded RuntimeHook server certificate %s", r.certFile)
+		case <-r.stopCh:
+			return
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// buildServerTLSConfig builds the tls.Config for the gRPC server according to Config,
+// including mTLS client verification, and starts certificate hot-reload.
+func buildServerTLSConfig(cfg *Config, stopCh <-chan struct{}) (*tls.Config, error) {
+	reloadable, err := newReloadableCertificate(cfg.ServerCertFile, cfg.ServerKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	go reloadable.watch(certReloadInterval, stopCh)
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloadable.GetCertificate,
+	}
+
+	if cfg.ClientCAFile != "" {
+		caBytes, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file %s: %w", cfg.ClientCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("no valid certificates found in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		if cfg.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	} else if cfg.RequireClientCert {
+		return nil, fmt.Errorf("RequireClientCert is set but ClientCAFile is empty")
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,333 @@
+/*
+ Copyright 2022 The Koordinator Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	runtimeapi "github.com/koordinator-sh/koordinator/pkg/runtimehooks/api/v1alpha1"
+)
+
+// testCA is a minimal self-signed CA used to mint short-lived server/client certs for the
+// TLS test matrix below.
+type testCA struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Unix(0, 0),
+		NotAfter:              time.Unix(0, 0).Add(100 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{
+		cert:    cert,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:     key,
+	}
+}
+
+func (ca *testCA) writePEM(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(path, ca.certPEM, 0o600))
+	return path
+}
+
+// issue mints a leaf certificate for cn/spiffeID signed by ca, and writes the cert/key pair
+// to dir, returning their paths.
+func (ca *testCA) issue(t *testing.T, dir, name, cn, spiffeID string) (certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(100 * 365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if spiffeID != "" {
+		u, err := url.Parse(spiffeID)
+		require.NoError(t, err)
+		tmpl.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	certPath = filepath.Join(dir, name+".crt")
+	keyPath = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+func dialAndCall(t *testing.T, addr string, tlsConfig *tls.Config) error {
+	dialOpts := []grpc.DialOption{grpc.WithBlock(), grpc.WithTimeout(5 * time.Second)}
+	if tlsConfig != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	conn, err := grpc.Dial(addr, dialOpts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := runtimeapi.NewRuntimeHookServiceClient(conn)
+	_, err = client.PreRunPodSandboxHook(context.Background(), &runtimeapi.PodSandboxHookRequest{})
+	return err
+}
+
+func Test_runtimeHook_Run_TLSMatrix(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caPath := ca.writePEM(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "server", "runtimehooks-server", "")
+	allowedClientCert, allowedClientKey := ca.issue(t, dir, "client-allowed", "containerd", "spiffe://cluster.local/ns/kube-system/sa/containerd")
+	rejectedClientCert, rejectedClientKey := ca.issue(t, dir, "client-rejected", "attacker", "")
+
+	tests := []struct {
+		name      string
+		config    *Config
+		clientTLS func() *tls.Config
+		wantErr   bool
+	}{
+		{
+			name:      "plaintext",
+			config:    &Config{RuntimeHooksNetwork: "tcp", RuntimeHooksAddr: ":0"},
+			clientTLS: func() *tls.Config { return nil },
+			wantErr:   false,
+		},
+		{
+			name: "one-way TLS accepts any client",
+			config: &Config{
+				RuntimeHooksNetwork: "tcp",
+				RuntimeHooksAddr:    ":0",
+				ServerCertFile:      serverCert,
+				ServerKeyFile:       serverKey,
+			},
+			clientTLS: func() *tls.Config {
+				pool := x509.NewCertPool()
+				pool.AppendCertsFromPEM(ca.certPEM)
+				return &tls.Config{RootCAs: pool, ServerName: "localhost"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "mTLS accepts allowlisted client",
+			config: &Config{
+				RuntimeHooksNetwork:    "tcp",
+				RuntimeHooksAddr:       ":0",
+				ServerCertFile:         serverCert,
+				ServerKeyFile:          serverKey,
+				ClientCAFile:           caPath,
+				RequireClientCert:      true,
+				AllowedClientCNs:       []string{"containerd"},
+				AllowedClientSPIFFEIDs: []string{"spiffe://cluster.local/ns/kube-system/sa/containerd"},
+			},
+			clientTLS: func() *tls.Config {
+				cert, err := tls.LoadX509KeyPair(allowedClientCert, allowedClientKey)
+				require.NoError(t, err)
+				pool := x509.NewCertPool()
+				pool.AppendCertsFromPEM(ca.certPEM)
+				return &tls.Config{RootCAs: pool, ServerName: "localhost", Certificates: []tls.Certificate{cert}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "mTLS rejects client outside allowlist",
+			config: &Config{
+				RuntimeHooksNetwork: "tcp",
+				RuntimeHooksAddr:    ":0",
+				ServerCertFile:      serverCert,
+				ServerKeyFile:       serverKey,
+				ClientCAFile:        caPath,
+				RequireClientCert:   true,
+				AllowedClientCNs:    []string{"containerd"},
+			},
+			clientTLS: func() *tls.Config {
+				cert, err := tls.LoadX509KeyPair(rejectedClientCert, rejectedClientKey)
+				require.NoError(t, err)
+				pool := x509.NewCertPool()
+				pool.AppendCertsFromPEM(ca.certPEM)
+				return &tls.Config{RootCAs: pool, ServerName: "localhost", Certificates: []tls.Certificate{cert}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "mTLS rejects client without certificate",
+			config: &Config{
+				RuntimeHooksNetwork: "tcp",
+				RuntimeHooksAddr:    ":0",
+				ServerCertFile:      serverCert,
+				ServerKeyFile:       serverKey,
+				ClientCAFile:        caPath,
+				RequireClientCert:   true,
+			},
+			clientTLS: func() *tls.Config {
+				pool := x509.NewCertPool()
+				pool.AppendCertsFromPEM(ca.certPEM)
+				return &tls.Config{RootCAs: pool, ServerName: "localhost"}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRuntimeHook(tt.config)
+			require.NoError(t, err)
+			rh := r.(*runtimeHook)
+
+			stop := make(chan struct{})
+			listener, err := rh.listen(stop)
+			require.NoError(t, err)
+			addr := listener.Addr().String()
+			listener.Close()
+			tt.config.RuntimeHooksAddr = addr
+
+			serveErr := make(chan error, 1)
+			go func() { serveErr <- r.Run(stop) }()
+			time.Sleep(100 * time.Millisecond)
+
+			err = dialAndCall(t, addr, tt.clientTLS())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			close(stop)
+			assert.NoError(t, <-serveErr)
+		})
+	}
+}
+
+// rotateCert overwrites certPath/keyPath in place with a freshly issued cert/key pair for cn,
+// backdating neither: os.Chtimes bumps the mtime forward so reloadableCertificate's
+// mtime-based change detection reliably sees it as newer, even on filesystems with coarse
+// mtime resolution.
+func rotateCert(t *testing.T, ca *testCA, dir, certPath, keyPath, cn string) *x509.Certificate {
+	newCertPath, newKeyPath := ca.issue(t, dir, cn+"-rotated", cn, "")
+
+	for src, dst := range map[string]string{newCertPath: certPath, newKeyPath: keyPath} {
+		data, err := os.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(dst, data, 0o600))
+	}
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(certPath, future, future))
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf
+}
+
+func Test_reloadableCertificate_reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPath, keyPath := ca.issue(t, dir, "server", "runtimehooks-server", "")
+
+	r, err := newReloadableCertificate(certPath, keyPath)
+	require.NoError(t, err)
+	original := r.current.Load().(*tls.Certificate)
+
+	rotated := rotateCert(t, ca, dir, certPath, keyPath, "runtimehooks-server-v2")
+
+	require.NoError(t, r.reload())
+	reloaded := r.current.Load().(*tls.Certificate)
+
+	assert.NotEqual(t, original.Certificate[0], reloaded.Certificate[0])
+	assert.Equal(t, rotated.Raw, reloaded.Certificate[0])
+}
+
+func Test_reloadableCertificate_reload_StatError(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPath, keyPath := ca.issue(t, dir, "server", "runtimehooks-server", "")
+
+	r, err := newReloadableCertificate(certPath, keyPath)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(certPath))
+	assert.Error(t, r.reload())
+
+	// A failed reload must not clobber the last good certificate GetCertificate serves.
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.NotNil(t, cert)
+}
+
+func Test_reloadableCertificate_watch_PicksUpRotation(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPath, keyPath := ca.issue(t, dir, "server", "runtimehooks-server", "")
+
+	r, err := newReloadableCertificate(certPath, keyPath)
+	require.NoError(t, err)
+	original := r.current.Load().(*tls.Certificate)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.watch(20*time.Millisecond, stop)
+
+	rotated := rotateCert(t, ca, dir, certPath, keyPath, "runtimehooks-server-v2")
+
+	require.Eventually(t, func() bool {
+		cert, err := r.GetCertificate(nil)
+		return err == nil && len(cert.Certificate) > 0 && string(cert.Certificate[0]) == string(rotated.Raw)
+	}, 2*time.Second, 10*time.Millisecond, "watch did not pick up the rotated certificate")
+
+	assert.NotEqual(t, original.Certificate[0], rotated.Raw)
+}
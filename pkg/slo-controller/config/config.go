@@ -18,10 +18,11 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/jinzhu/copier"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -38,6 +39,13 @@ type ColocationCfg struct {
 
 type NodeColocationCfg struct {
 	NodeSelector *metav1.LabelSelector
+	// Priority decides which NodeColocationCfg wins when more than one matches the same
+	// node: entries are folded in ascending priority order, so a higher Priority's non-nil
+	// fields overwrite a lower one's. Nil is treated as priority 0. Ties are broken by
+	// selector specificity (the entry matching on more labels/expressions wins), and
+	// further ties by the entry's position in NodeConfigs (the later one wins).
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
 	ColocationCfg
 }
 
@@ -82,10 +90,10 @@ func IsColocationStrategyValid(strategy *ColocationStrategy) bool {
 }
 
 func IsNodeColocationCfgValid(nodeCfg *NodeColocationCfg) bool {
-	if nodeCfg == nil {
+	if nodeCfg == nil || nodeCfg.NodeSelector == nil {
 		return false
 	}
-	if nodeCfg.NodeSelector.MatchLabels == nil {
+	if len(nodeCfg.NodeSelector.MatchLabels) == 0 && len(nodeCfg.NodeSelector.MatchExpressions) == 0 {
 		return false
 	}
 	if _, err := metav1.LabelSelectorAsSelector(nodeCfg.NodeSelector); err != nil {
@@ -95,36 +103,102 @@ func IsNodeColocationCfgValid(nodeCfg *NodeColocationCfg) bool {
 	return !reflect.DeepEqual(&nodeCfg.ColocationStrategy, &ColocationStrategy{})
 }
 
-func GetNodeColocationStrategy(cfg *ColocationCfg, node *corev1.Node) *ColocationStrategy {
-	if cfg == nil || node == nil {
-		return nil
-	}
-
-	strategy := &ColocationStrategy{}
-	if err := copier.Copy(&strategy, &cfg.ColocationStrategy); err != nil {
-		return nil
-	}
+// matchedNodeColocationCfg is a NodeColocationCfg that matched a node, along with the
+// information GetNodeColocationStrategyWithTrace needs to order and explain the merge.
+type matchedNodeColocationCfg struct {
+	index       int
+	priority    int32
+	specificity int
+	cfg         *NodeColocationCfg
+}
 
+// matchNodeColocationCfgs returns the NodeConfigs of cfg that select node, sorted in the
+// ascending order they should be folded in: lowest priority first, so a later (higher
+// priority, or equally-prioritized but more specific) entry overwrites the prior ones' set
+// fields. Ties in both priority and specificity keep the original NodeConfigs order, so the
+// later entry in the list wins deterministically.
+func matchNodeColocationCfgs(cfg *ColocationCfg, node *corev1.Node) []matchedNodeColocationCfg {
 	nodeLabels := labels.Set(node.Labels)
-	for _, nodeCfg := range cfg.NodeConfigs {
+	var matched []matchedNodeColocationCfg
+	for i := range cfg.NodeConfigs {
+		nodeCfg := &cfg.NodeConfigs[i]
+		if nodeCfg.NodeSelector == nil {
+			continue
+		}
 		selector, err := metav1.LabelSelectorAsSelector(nodeCfg.NodeSelector)
 		if err != nil {
 			continue
 		}
-		if selector.Matches(nodeLabels) {
-			if nodeCfg.NodeSelector != nil {
-				if merged, err := util.Merge(strategy, &nodeCfg.ColocationStrategy); err != nil {
-					continue
-				} else {
-					strategy, _ = merged.(*ColocationStrategy)
-				}
-			}
-			break
+		if !selector.Matches(nodeLabels) {
+			continue
+		}
+		var priority int32
+		if nodeCfg.Priority != nil {
+			priority = *nodeCfg.Priority
 		}
+		matched = append(matched, matchedNodeColocationCfg{
+			index:       i,
+			priority:    priority,
+			specificity: len(nodeCfg.NodeSelector.MatchLabels) + len(nodeCfg.NodeSelector.MatchExpressions),
+			cfg:         nodeCfg,
+		})
 	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].priority != matched[j].priority {
+			return matched[i].priority < matched[j].priority
+		}
+		if matched[i].specificity != matched[j].specificity {
+			return matched[i].specificity < matched[j].specificity
+		}
+		return matched[i].index < matched[j].index
+	})
+	return matched
+}
+
+// mergeColocationStrategyInto overwrites dst's fields with every non-nil field of src,
+// recording in trace (when non-nil) which source last set each field.
+func mergeColocationStrategyInto(dst, src *ColocationStrategy, source string, trace map[string]string) {
+	dstVal := reflect.ValueOf(dst).Elem()
+	srcVal := reflect.ValueOf(src).Elem()
+	t := dstVal.Type()
+	for i := 0; i < t.NumField(); i++ {
+		srcField := srcVal.Field(i)
+		if srcField.IsNil() {
+			continue
+		}
+		dstVal.Field(i).Set(srcField)
+		if trace != nil {
+			trace[t.Field(i).Name] = source
+		}
+	}
+}
+
+// GetNodeColocationStrategy resolves the effective ColocationStrategy for node: the
+// cluster-wide default overlaid by every matching NodeColocationCfg, highest priority last.
+func GetNodeColocationStrategy(cfg *ColocationCfg, node *corev1.Node) *ColocationStrategy {
+	strategy, _ := GetNodeColocationStrategyWithTrace(cfg, node)
 	return strategy
 }
 
+// GetNodeColocationStrategyWithTrace is GetNodeColocationStrategy plus a trace of which
+// NodeConfigs entry (identified as "nodeConfigs[<index>]", or "default" for the cluster-wide
+// strategy) last set each non-nil field of the result, for debugging overlapping selectors.
+func GetNodeColocationStrategyWithTrace(cfg *ColocationCfg, node *corev1.Node) (*ColocationStrategy, map[string]string) {
+	if cfg == nil || node == nil {
+		return nil, nil
+	}
+
+	strategy := &ColocationStrategy{}
+	trace := map[string]string{}
+	mergeColocationStrategyInto(strategy, &cfg.ColocationStrategy, "default", trace)
+
+	for _, m := range matchNodeColocationCfgs(cfg, node) {
+		mergeColocationStrategyInto(strategy, &m.cfg.ColocationStrategy, fmt.Sprintf("nodeConfigs[%d]", m.index), trace)
+	}
+	return strategy, trace
+}
+
 type Configuration struct {
 	FeatureGates                        map[string]bool
 	ClientQPS                           int
@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The Koordinator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/koordinator-sh/koordinator/pkg/util"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func Test_GetNodeColocationStrategy(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"zone":          "zone-a",
+				"node-role/gpu": "true",
+				"topology/rack": "rack-1",
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		cfg  *ColocationCfg
+		want *ColocationStrategy
+	}{
+		{
+			name: "no node configs falls back to cluster default",
+			cfg: &ColocationCfg{
+				ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(60)},
+			},
+			want: &ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(60)},
+		},
+		{
+			name: "higher priority wins over lower priority",
+			cfg: &ColocationCfg{
+				ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(60)},
+				NodeConfigs: []NodeColocationCfg{
+					{
+						NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "zone-a"}},
+						Priority:     int32Ptr(1),
+						ColocationCfg: ColocationCfg{
+							ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(70)},
+						},
+					},
+					{
+						NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"node-role/gpu": "true"}},
+						Priority:     int32Ptr(10),
+						ColocationCfg: ColocationCfg{
+							ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(80)},
+						},
+					},
+				},
+			},
+			want: &ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(80)},
+		},
+		{
+			name: "equal priority breaks tie on selector specificity",
+			cfg: &ColocationCfg{
+				ColocationStrategy: ColocationStrategy{},
+				NodeConfigs: []NodeColocationCfg{
+					{
+						NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "zone-a"}},
+						Priority:     int32Ptr(5),
+						ColocationCfg: ColocationCfg{
+							ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(70)},
+						},
+					},
+					{
+						NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+							"zone":          "zone-a",
+							"node-role/gpu": "true",
+						}},
+						Priority: int32Ptr(5),
+						ColocationCfg: ColocationCfg{
+							ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(90)},
+						},
+					},
+				},
+			},
+			want: &ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(90)},
+		},
+		{
+			name: "MatchExpressions selector is honored",
+			cfg: &ColocationCfg{
+				NodeConfigs: []NodeColocationCfg{
+					{
+						NodeSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: "node-role/gpu", Operator: metav1.LabelSelectorOpExists},
+							},
+						},
+						ColocationCfg: ColocationCfg{
+							ColocationStrategy: ColocationStrategy{MemoryReclaimThresholdPercent: util.Int64Ptr(75)},
+						},
+					},
+				},
+			},
+			want: &ColocationStrategy{MemoryReclaimThresholdPercent: util.Int64Ptr(75)},
+		},
+		{
+			name: "nil fields never override a set default",
+			cfg: &ColocationCfg{
+				ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(60)},
+				NodeConfigs: []NodeColocationCfg{
+					{
+						NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "zone-a"}},
+						ColocationCfg: ColocationCfg{
+							ColocationStrategy: ColocationStrategy{MemoryReclaimThresholdPercent: util.Int64Ptr(55)},
+						},
+					},
+				},
+			},
+			want: &ColocationStrategy{
+				CPUReclaimThresholdPercent:    util.Int64Ptr(60),
+				MemoryReclaimThresholdPercent: util.Int64Ptr(55),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetNodeColocationStrategy(tt.cfg, node)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_GetNodeColocationStrategyWithTrace(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"zone": "zone-a"}}}
+	cfg := &ColocationCfg{
+		ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(60)},
+		NodeConfigs: []NodeColocationCfg{
+			{
+				NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "zone-a"}},
+				ColocationCfg: ColocationCfg{
+					ColocationStrategy: ColocationStrategy{MemoryReclaimThresholdPercent: util.Int64Ptr(55)},
+				},
+			},
+		},
+	}
+
+	strategy, trace := GetNodeColocationStrategyWithTrace(cfg, node)
+	assert.Equal(t, util.Int64Ptr(60), strategy.CPUReclaimThresholdPercent)
+	assert.Equal(t, "default", trace["CPUReclaimThresholdPercent"])
+	assert.Equal(t, "nodeConfigs[0]", trace["MemoryReclaimThresholdPercent"])
+}
+
+func Test_IsNodeColocationCfgValid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *NodeColocationCfg
+		want bool
+	}{
+		{name: "nil", cfg: nil, want: false},
+		{
+			name: "nil selector",
+			cfg:  &NodeColocationCfg{},
+			want: false,
+		},
+		{
+			name: "empty selector",
+			cfg:  &NodeColocationCfg{NodeSelector: &metav1.LabelSelector{}},
+			want: false,
+		},
+		{
+			name: "MatchLabels with empty strategy is invalid",
+			cfg: &NodeColocationCfg{
+				NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "zone-a"}},
+			},
+			want: false,
+		},
+		{
+			name: "MatchExpressions with a set strategy is valid",
+			cfg: &NodeColocationCfg{
+				NodeSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: metav1.LabelSelectorOpExists},
+					},
+				},
+				ColocationCfg: ColocationCfg{
+					ColocationStrategy: ColocationStrategy{CPUReclaimThresholdPercent: util.Int64Ptr(60)},
+				},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsNodeColocationCfgValid(tt.cfg))
+		})
+	}
+}